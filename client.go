@@ -0,0 +1,353 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	ErrConnect          = errors.New("couldn't connect to SSH agent")
+	ErrIdentity         = errors.New("no identity found")
+	ErrKeepAliveTimeout = errors.New("ssh keepalive timed out, connection presumed dead")
+)
+
+// Client is implemented by both the SSH and Localhost clients, letting
+// Stackup.Run() treat every host the same way.
+type Client interface {
+	Connect(host string) error
+	Run(task *Task) error
+	Wait() error
+	Signal(sig os.Signal) error
+	Stdin() io.WriteCloser
+	Stdout() io.Reader
+	Stderr() io.Reader
+	WriteClose() error
+	Close() error
+	Prefix() (string, int)
+	Host() string
+}
+
+// SSHClient runs tasks on a single remote host over SSH.
+type SSHClient struct {
+	conn *ssh.Client
+	sess *ssh.Session
+
+	user  string
+	host  string
+	color string
+	env   string
+
+	remoteStdin  io.WriteCloser
+	remoteStdout io.Reader
+	remoteStderr io.Reader
+
+	connOpened bool
+	sessOpened bool
+	running    bool
+
+	ConnectTimeout int
+
+	// keepAliveInterval and keepAliveMaxDelay configure the keepalive
+	// probe loop started in Connect/ConnectWith. See keepalive.go.
+	keepAliveInterval  time.Duration
+	keepAliveMaxDelay  time.Duration
+	keepAliveLastSeen  time.Time
+	keepAliveMu        sync.Mutex
+	keepAliveCancel    context.CancelFunc
+	onKeepAliveTimeout func(ClientError)
+}
+
+func sshDialTimeout(network, addr string, config *ssh.ClientConfig, timeout int) (*ssh.Client, error) {
+	if timeout <= 0 {
+		return ssh.Dial(network, addr, config)
+	}
+	conn, err := net.DialTimeout(network, addr, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+func sshClientConfig(user string) (*ssh.ClientConfig, error) {
+	sock, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+	if err != nil {
+		return nil, errors.Wrap(ErrConnect, err.Error())
+	}
+	agentClient := agent.NewClient(sock)
+	signers, err := agentClient.Signers()
+	if err != nil {
+		return nil, errors.Wrap(ErrIdentity, err.Error())
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}, nil
+}
+
+// parseHost splits a "user@host:port" spec into its parts, defaulting the
+// user to the current OS user and the port to 22.
+func parseHost(rawHost string) (user, host, port string, err error) {
+	if i := strings.Index(rawHost, "@"); i >= 0 {
+		user, rawHost = rawHost[:i], rawHost[i+1:]
+	} else {
+		u, uerr := osUser()
+		if uerr != nil {
+			return "", "", "", uerr
+		}
+		user = u
+	}
+	host, port, err = net.SplitHostPort(rawHost)
+	if err != nil {
+		host, port = rawHost, "22"
+		err = nil
+	}
+	return user, host, port, err
+}
+
+func osUser() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// Connect connects to the remote host directly. Use ConnectWith to route
+// the connection through an already-connected bastion client.
+func (c *SSHClient) Connect(host string) error {
+	return c.ConnectWith(host, sshDialTimeout)
+}
+
+// DialFunc dials an SSH network connection, optionally routing it through
+// another client (a bastion host).
+type DialFunc func(network, addr string, config *ssh.ClientConfig, timeout int) (*ssh.Client, error)
+
+// ConnectWith connects to the remote host using the given dial function,
+// so a bastion client's DialThrough can be passed in to tunnel the
+// connection.
+func (c *SSHClient) ConnectWith(host string, dialer DialFunc) error {
+	if c.connOpened {
+		return fmt.Errorf("already connected")
+	}
+
+	user, host, port, err := parseHost(host)
+	if err != nil {
+		return err
+	}
+	if c.user != "" {
+		user = c.user
+	}
+
+	config, err := sshClientConfig(user)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dialer("tcp", net.JoinHostPort(host, port), config, c.ConnectTimeout)
+	if err != nil {
+		return errors.Wrap(err, "ssh dial failed")
+	}
+
+	c.conn = conn
+	c.host = host
+	c.connOpened = true
+
+	c.startKeepAlive()
+
+	return nil
+}
+
+// DialThrough lets this client act as a bastion: it tunnels a connection
+// to addr through its own already-established SSH connection.
+func (c *SSHClient) DialThrough(network, addr string, config *ssh.ClientConfig, timeout int) (*ssh.Client, error) {
+	conn, err := c.conn.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+func (c *SSHClient) Run(task *Task) error {
+	if c.sessOpened {
+		return fmt.Errorf("session already started")
+	}
+	sess, err := c.conn.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "session creation failed")
+	}
+
+	if task.TTY {
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          0,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err := sess.RequestPty("xterm", 80, 40, modes); err != nil {
+			return errors.Wrap(err, "request for pseudo terminal failed")
+		}
+	}
+
+	c.remoteStdin, err = sess.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "stdin pipe failed")
+	}
+	c.remoteStdout, err = sess.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "stdout pipe failed")
+	}
+	c.remoteStderr, err = sess.StderrPipe()
+	if err != nil {
+		return errors.Wrap(err, "stderr pipe failed")
+	}
+
+	if err := sess.Start(c.env + task.Run); err != nil {
+		return errors.Wrap(err, "remote command failed to start")
+	}
+
+	c.sess = sess
+	c.sessOpened = true
+	c.running = true
+
+	return nil
+}
+
+func (c *SSHClient) Wait() error {
+	if !c.sessOpened {
+		return fmt.Errorf("session not yet started")
+	}
+	err := c.sess.Wait()
+	c.sess.Close()
+	c.sessOpened = false
+	c.running = false
+	return err
+}
+
+func (c *SSHClient) Signal(sig os.Signal) error {
+	if !c.sessOpened {
+		return fmt.Errorf("session not yet started")
+	}
+	switch sig {
+	case os.Interrupt:
+		return c.sess.Signal(ssh.SIGINT)
+	default:
+		return fmt.Errorf("%v not supported", sig)
+	}
+}
+
+func (c *SSHClient) Stdin() io.WriteCloser {
+	return c.remoteStdin
+}
+
+func (c *SSHClient) Stdout() io.Reader {
+	return c.remoteStdout
+}
+
+func (c *SSHClient) Stderr() io.Reader {
+	return c.remoteStderr
+}
+
+func (c *SSHClient) WriteClose() error {
+	return c.remoteStdin.Close()
+}
+
+func (c *SSHClient) Close() error {
+	c.stopKeepAlive()
+	if c.sessOpened {
+		c.sess.Close()
+		c.sessOpened = false
+	}
+	if c.connOpened {
+		err := c.conn.Close()
+		c.connOpened = false
+		return err
+	}
+	return nil
+}
+
+func (c *SSHClient) Prefix() (string, int) {
+	prefix := fmt.Sprintf("%s[%s] ", c.color, c.host)
+	return prefix, len(c.host) + 1
+}
+
+func (c *SSHClient) Host() string {
+	return c.host
+}
+
+// LocalhostClient runs tasks on the local machine instead of over SSH,
+// useful for "localhost" entries in a network.
+type LocalhostClient struct {
+	env  string
+	host string
+
+	remoteStdin  io.WriteCloser
+	remoteStdout io.Reader
+	remoteStderr io.Reader
+}
+
+func (c *LocalhostClient) Connect(host string) error {
+	c.host = host
+	return nil
+}
+
+func (c *LocalhostClient) Run(task *Task) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (c *LocalhostClient) Wait() error {
+	return nil
+}
+
+func (c *LocalhostClient) Signal(sig os.Signal) error {
+	return nil
+}
+
+func (c *LocalhostClient) Stdin() io.WriteCloser {
+	return c.remoteStdin
+}
+
+func (c *LocalhostClient) Stdout() io.Reader {
+	return c.remoteStdout
+}
+
+func (c *LocalhostClient) Stderr() io.Reader {
+	return c.remoteStderr
+}
+
+func (c *LocalhostClient) WriteClose() error {
+	if c.remoteStdin != nil {
+		return c.remoteStdin.Close()
+	}
+	return nil
+}
+
+func (c *LocalhostClient) Close() error {
+	return nil
+}
+
+func (c *LocalhostClient) Prefix() (string, int) {
+	return fmt.Sprintf("[%s] ", c.host), len(c.host) + 1
+}
+
+func (c *LocalhostClient) Host() string {
+	return c.host
+}
@@ -0,0 +1,119 @@
+// Command sup runs the commands defined in a Supfile against a network of
+// hosts. It is the sole place in this codebase allowed to call os.Exit:
+// Stackup.Run reports every failure through its returned error instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sntw92/sup"
+)
+
+func main() {
+	var (
+		supfilePath     = flag.String("f", "./Sup.yml", "Custom path to Supfile")
+		timeout         = flag.Int("timeout", 30, "Timeout (seconds) for SSH connect")
+		debug           = flag.Bool("D", false, "Enable debug mode")
+		disablePrefix   = flag.Bool("disable-prefix", false, "Disable the hostname prefix on output lines")
+		ignoreErrors    = flag.Bool("ignore-errors", false, "Don't stop the run when a host fails")
+		summaryFile     = flag.String("summary", "", "Write a JSON summary of failures to this file")
+		format          = flag.String("format", "text", "Output format: \"text\" or \"jsonl\" (one JSON object per event, to stdout)")
+		maxParallel     = flag.Int("max-parallel", 0, "Limit how many hosts connect, or run a task, at once (0 = unlimited, or 1 on a \"rolling\" network)")
+		retryAttempts   = flag.Int("retry", 0, "Retry a failed host connection this many times (0 disables retrying)")
+		retryBackoff    = flag.Duration("retry-backoff", time.Second, "Initial backoff between connection retries")
+		retryMaxBackoff = flag.Duration("retry-max-backoff", 30*time.Second, "Maximum backoff between connection retries")
+	)
+	var envFlags envVarFlags
+	flag.Var(&envFlags, "e", "Set environment variable NAME=value (may be repeated)")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Usage: sup [OPTIONS] NETWORK COMMAND [COMMAND...]")
+		os.Exit(1)
+	}
+	networkName, cmdNames := args[0], args[1:]
+
+	conf, err := sup.LoadSupfile(*supfilePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	network, ok := conf.Networks[networkName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown network %q\n", networkName)
+		os.Exit(1)
+	}
+
+	var commands []*sup.Command
+	for _, name := range cmdNames {
+		cmd, ok := conf.Commands[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+			os.Exit(1)
+		}
+		commands = append(commands, &cmd)
+	}
+
+	env := append(sup.EnvList{}, conf.Env...)
+	env = append(env, network.Env...)
+	for _, kv := range envFlags {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "invalid -e value %q, want NAME=value\n", kv)
+			os.Exit(1)
+		}
+		env = append(env, sup.EnvVar{Key: name, Value: value})
+	}
+
+	stackup, err := sup.New(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	stackup.Debug(*debug)
+	stackup.Prefix(!*disablePrefix)
+	stackup.IgnoreError(*ignoreErrors)
+	stackup.Summary(*summaryFile)
+	stackup.SetSshConnectionTimeout(*timeout)
+	stackup.SetMaxParallel(*maxParallel)
+	if *retryAttempts > 0 {
+		stackup.SetConnectRetry(*retryAttempts, *retryBackoff, *retryMaxBackoff, 1.0)
+	}
+
+	switch *format {
+	case "text":
+	case "jsonl":
+		stackup.DisableDefaultReporter()
+		stackup.AddReporter(sup.NewJSONLReporter(os.Stdout))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -format %q, want \"text\" or \"jsonl\"\n", *format)
+		os.Exit(1)
+	}
+
+	if err := stackup.Run(&network, env, commands...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if runErr, ok := err.(*sup.RunError); ok {
+			os.Exit(runErr.ExitCode())
+		}
+		os.Exit(1)
+	}
+}
+
+// envVarFlags implements flag.Value, collecting repeated -e NAME=value
+// flags into a slice.
+type envVarFlags []string
+
+func (f *envVarFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *envVarFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
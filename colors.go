@@ -0,0 +1,12 @@
+package sup
+
+// Colors are the ANSI escape sequences cycled through to give each host
+// a distinct prefix color in TextReporter output.
+var Colors = []string{
+	"\033[0;31m", // red
+	"\033[0;32m", // green
+	"\033[0;33m", // yellow
+	"\033[0;34m", // blue
+	"\033[0;35m", // magenta
+	"\033[0;36m", // cyan
+}
@@ -0,0 +1,96 @@
+package sup
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultKeepAliveInterval = 2 * time.Second
+	defaultKeepAliveMaxDelay = 120 * time.Second
+)
+
+// SetSshKeepAlive configures the SSH keepalive probe sent on every
+// connection opened by Run: a "keepalive@openssh.com" request is sent
+// every interval, and the connection is considered dead (and closed) if
+// no reply has been seen within maxDelay. A zero interval or maxDelay
+// falls back to the default of 2s / 120s.
+func (sup *Stackup) SetSshKeepAlive(interval, maxDelay time.Duration) {
+	sup.sshKeepAliveInterval = interval
+	sup.sshKeepAliveMaxDelay = maxDelay
+}
+
+// startKeepAlive launches the background probe goroutine for an already
+// connected client. It is a no-op if called more than once; stopKeepAlive
+// (called from Close) stops it.
+func (c *SSHClient) startKeepAlive() {
+	if c.keepAliveCancel != nil {
+		return
+	}
+
+	interval := c.keepAliveInterval
+	if interval <= 0 {
+		interval = defaultKeepAliveInterval
+	}
+	maxDelay := c.keepAliveMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultKeepAliveMaxDelay
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.keepAliveCancel = cancel
+	c.setKeepAliveSeen(time.Now())
+
+	go c.keepAliveLoop(ctx, interval, maxDelay)
+}
+
+func (c *SSHClient) stopKeepAlive() {
+	if c.keepAliveCancel != nil {
+		c.keepAliveCancel()
+		c.keepAliveCancel = nil
+	}
+}
+
+func (c *SSHClient) setKeepAliveSeen(t time.Time) {
+	c.keepAliveMu.Lock()
+	c.keepAliveLastSeen = t
+	c.keepAliveMu.Unlock()
+}
+
+func (c *SSHClient) keepAliveSilence() time.Duration {
+	c.keepAliveMu.Lock()
+	defer c.keepAliveMu.Unlock()
+	return time.Since(c.keepAliveLastSeen)
+}
+
+func (c *SSHClient) keepAliveLoop(ctx context.Context, interval, maxDelay time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			go func() {
+				ok, _, err := c.conn.SendRequest("keepalive@openssh.com", true, nil)
+				if err == nil && ok {
+					c.setKeepAliveSeen(time.Now())
+				}
+			}()
+
+			if c.keepAliveSilence() > maxDelay {
+				if c.onKeepAliveTimeout != nil {
+					c.onKeepAliveTimeout(ClientError{
+						Host:     c.host,
+						Type:     "conn",
+						Err:      ErrKeepAliveTimeout,
+						ExitCode: -1,
+					})
+				}
+				c.Close()
+				return
+			}
+		}
+	}
+}
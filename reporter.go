@@ -0,0 +1,208 @@
+package sup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Reporter receives streaming events as Stackup.Run executes a command
+// across a network's hosts. Registering one or more Reporters with
+// AddReporter decouples I/O from orchestration: Run hands every line a
+// task writes to stdout/stderr to the reporters instead of writing it
+// itself. Run's own diagnostics (such as connection or read failures)
+// still go straight to os.Stderr.
+type Reporter interface {
+	OnTaskStart(host, task string)
+	OnStdoutLine(host, task, line string)
+	OnStderrLine(host, task, line string)
+	OnTaskEnd(host, task string, exitCode int, err error)
+	OnRunEnd(summary []ClientError)
+}
+
+// AddReporter registers an additional Reporter. Reporters are notified in
+// the order they were added; Stackup always notifies the TextReporter
+// installed by New() first, unless it was removed by
+// DisableDefaultReporter.
+func (sup *Stackup) AddReporter(r Reporter) {
+	sup.reporters = append(sup.reporters, r)
+}
+
+// DisableDefaultReporter removes the TextReporter installed by New from
+// the reporter list, so it no longer writes human-readable lines to
+// Stdout/Stderr. Use this before adding a Reporter meant to be the sole
+// consumer of a stream, such as JSONLReporter on Stdout.
+func (sup *Stackup) DisableDefaultReporter() {
+	for i, r := range sup.reporters {
+		if r == sup.defaultReporter {
+			sup.reporters = append(sup.reporters[:i], sup.reporters[i+1:]...)
+			return
+		}
+	}
+}
+
+// hostPrefixer is implemented by reporters that want to know the
+// left-padded, colorized prefix Stackup has computed for a host before
+// any output for that host is reported.
+type hostPrefixer interface {
+	setHostPrefix(host, prefix string)
+}
+
+func (sup *Stackup) setHostPrefixes(clients []Client, maxLen int) {
+	for _, c := range clients {
+		prefix, prefixLen := c.Prefix()
+		if prefixLen < maxLen {
+			prefix = spaces(maxLen-prefixLen) + prefix
+		}
+		for _, r := range sup.reporters {
+			if hp, ok := r.(hostPrefixer); ok {
+				hp.setHostPrefix(c.Host(), prefix)
+			}
+		}
+	}
+}
+
+func spaces(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	return string(b)
+}
+
+func (sup *Stackup) reportTaskStart(host, task string) {
+	for _, r := range sup.reporters {
+		r.OnTaskStart(host, task)
+	}
+}
+
+func (sup *Stackup) reportStdoutLine(host, task, line string) {
+	for _, r := range sup.reporters {
+		r.OnStdoutLine(host, task, line)
+	}
+}
+
+func (sup *Stackup) reportStderrLine(host, task, line string) {
+	for _, r := range sup.reporters {
+		r.OnStderrLine(host, task, line)
+	}
+}
+
+func (sup *Stackup) reportTaskEnd(host, task string, exitCode int, err error) {
+	for _, r := range sup.reporters {
+		r.OnTaskEnd(host, task, exitCode, err)
+	}
+}
+
+func (sup *Stackup) reportRunEnd(summary []ClientError) {
+	for _, r := range sup.reporters {
+		r.OnRunEnd(summary)
+	}
+}
+
+// TextReporter reproduces sup's original behavior: lines are written to
+// Stdout/Stderr with a left-padded, colorized "[host] " prefix.
+type TextReporter struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	mu      sync.Mutex
+	prefix  map[string]string
+	enabled bool
+}
+
+// NewTextReporter creates a TextReporter writing to out/err. Prefixes are
+// only emitted when prefix is true, matching Stackup.Prefix(true).
+func NewTextReporter(out, errOut io.Writer, prefix bool) *TextReporter {
+	return &TextReporter{Stdout: out, Stderr: errOut, prefix: map[string]string{}, enabled: prefix}
+}
+
+func (r *TextReporter) setHostPrefix(host, prefix string) {
+	r.mu.Lock()
+	r.prefix[host] = prefix
+	r.mu.Unlock()
+}
+
+func (r *TextReporter) hostPrefix(host string) string {
+	if !r.enabled {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.prefix[host]
+}
+
+func (r *TextReporter) OnTaskStart(host, task string) {}
+
+func (r *TextReporter) OnStdoutLine(host, task, line string) {
+	fmt.Fprintf(r.Stdout, "%s%s\n", r.hostPrefix(host), line)
+}
+
+func (r *TextReporter) OnStderrLine(host, task, line string) {
+	fmt.Fprintf(r.Stderr, "%s%s\n", r.hostPrefix(host), line)
+}
+
+func (r *TextReporter) OnTaskEnd(host, task string, exitCode int, err error) {
+	if err != nil {
+		fmt.Fprintf(r.Stderr, "%s%v\n", r.hostPrefix(host), err)
+	}
+}
+
+func (r *TextReporter) OnRunEnd(summary []ClientError) {}
+
+// JSONLReporter emits one JSON object per event to w (newline-delimited),
+// so CI systems and dashboards can consume sup's output structurally.
+type JSONLReporter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewJSONLReporter creates a JSONLReporter writing to w.
+func NewJSONLReporter(w io.Writer) *JSONLReporter {
+	return &JSONLReporter{w: w}
+}
+
+type jsonlEvent struct {
+	Event    string        `json:"event"`
+	Host     string        `json:"host,omitempty"`
+	Task     string        `json:"task,omitempty"`
+	Line     string        `json:"line,omitempty"`
+	ExitCode int           `json:"exit_code,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Summary  []ClientError `json:"summary,omitempty"`
+}
+
+func (r *JSONLReporter) emit(e jsonlEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write(append(data, '\n'))
+}
+
+func (r *JSONLReporter) OnTaskStart(host, task string) {
+	r.emit(jsonlEvent{Event: "task_start", Host: host, Task: task})
+}
+
+func (r *JSONLReporter) OnStdoutLine(host, task, line string) {
+	r.emit(jsonlEvent{Event: "stdout", Host: host, Task: task, Line: line})
+}
+
+func (r *JSONLReporter) OnStderrLine(host, task, line string) {
+	r.emit(jsonlEvent{Event: "stderr", Host: host, Task: task, Line: line})
+}
+
+func (r *JSONLReporter) OnTaskEnd(host, task string, exitCode int, err error) {
+	event := jsonlEvent{Event: "task_end", Host: host, Task: task, ExitCode: exitCode}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.emit(event)
+}
+
+func (r *JSONLReporter) OnRunEnd(summary []ClientError) {
+	r.emit(jsonlEvent{Event: "run_end", Summary: summary})
+}
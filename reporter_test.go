@@ -0,0 +1,135 @@
+package sup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countingReporter counts how many times each callback fired, so fan-out
+// tests can assert every registered Reporter was notified.
+type countingReporter struct {
+	taskStarts int
+	stdout     int
+	stderr     int
+	taskEnds   int
+	runEnds    int
+}
+
+func (r *countingReporter) OnTaskStart(host, task string)                        { r.taskStarts++ }
+func (r *countingReporter) OnStdoutLine(host, task, line string)                 { r.stdout++ }
+func (r *countingReporter) OnStderrLine(host, task, line string)                 { r.stderr++ }
+func (r *countingReporter) OnTaskEnd(host, task string, exitCode int, err error) { r.taskEnds++ }
+func (r *countingReporter) OnRunEnd(summary []ClientError)                       { r.runEnds++ }
+
+func TestReportersFanOut(t *testing.T) {
+	a, b := &countingReporter{}, &countingReporter{}
+	sup := &Stackup{reporters: []Reporter{a, b}}
+
+	sup.reportTaskStart("host1", "deploy")
+	sup.reportStdoutLine("host1", "deploy", "line")
+	sup.reportStderrLine("host1", "deploy", "line")
+	sup.reportTaskEnd("host1", "deploy", 0, nil)
+	sup.reportRunEnd(nil)
+
+	for name, r := range map[string]*countingReporter{"a": a, "b": b} {
+		if r.taskStarts != 1 || r.stdout != 1 || r.stderr != 1 || r.taskEnds != 1 || r.runEnds != 1 {
+			t.Errorf("reporter %s: got %+v, want every callback fired exactly once", name, r)
+		}
+	}
+}
+
+func TestDisableDefaultReporter(t *testing.T) {
+	text := NewTextReporter(new(bytes.Buffer), new(bytes.Buffer), false)
+	sup := &Stackup{defaultReporter: text, reporters: []Reporter{text}}
+
+	extra := &countingReporter{}
+	sup.AddReporter(extra)
+	if len(sup.reporters) != 2 {
+		t.Fatalf("len(reporters) = %d, want 2", len(sup.reporters))
+	}
+
+	sup.DisableDefaultReporter()
+	if len(sup.reporters) != 1 || sup.reporters[0] != extra {
+		t.Fatalf("DisableDefaultReporter did not remove the default reporter: %+v", sup.reporters)
+	}
+
+	// Calling it again (no default reporter left) must be a no-op, not a panic.
+	sup.DisableDefaultReporter()
+	if len(sup.reporters) != 1 {
+		t.Fatalf("len(reporters) = %d after second DisableDefaultReporter, want 1", len(sup.reporters))
+	}
+}
+
+func TestTextReporterPrefix(t *testing.T) {
+	var out, errOut bytes.Buffer
+	r := NewTextReporter(&out, &errOut, true)
+	r.setHostPrefix("host1", "[host1] ")
+
+	r.OnStdoutLine("host1", "deploy", "building")
+	r.OnStderrLine("host1", "deploy", "warning")
+	r.OnTaskEnd("host1", "deploy", 1, errors.New("boom"))
+
+	if got, want := out.String(), "[host1] building\n"; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+	if got, want := errOut.String(), "[host1] warning\n[host1] boom\n"; got != want {
+		t.Errorf("Stderr = %q, want %q", got, want)
+	}
+}
+
+func TestTextReporterNoPrefixWhenDisabled(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter(&out, new(bytes.Buffer), false)
+	r.setHostPrefix("host1", "[host1] ")
+
+	r.OnStdoutLine("host1", "deploy", "building")
+	if got, want := out.String(), "building\n"; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+}
+
+func TestJSONLReporterEmitsOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONLReporter(&buf)
+
+	r.OnTaskStart("host1", "deploy")
+	r.OnStdoutLine("host1", "deploy", "building")
+	r.OnStderrLine("host1", "deploy", "warning")
+	r.OnTaskEnd("host1", "deploy", 1, errors.New("boom"))
+	r.OnRunEnd([]ClientError{{Host: "host1", Type: "run", Err: errors.New("boom"), ExitCode: 1}})
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5:\n%s", len(lines), buf.String())
+	}
+
+	wantEvents := []string{"task_start", "stdout", "stderr", "task_end", "run_end"}
+	for i, line := range lines {
+		// Decode only the event name: the run_end summary's error field is
+		// a string, which can't be decoded back into ClientError.Err.
+		var e struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %d: invalid JSON: %v", i, err)
+		}
+		if e.Event != wantEvents[i] {
+			t.Errorf("line %d: event = %q, want %q", i, e.Event, wantEvents[i])
+		}
+	}
+
+	var taskEnd jsonlEvent
+	json.Unmarshal([]byte(lines[3]), &taskEnd)
+	if taskEnd.ExitCode != 1 || taskEnd.Error != "boom" {
+		t.Errorf("task_end = %+v, want ExitCode=1 Error=boom", taskEnd)
+	}
+
+	var runEnd jsonlEvent
+	json.Unmarshal([]byte(lines[4]), &runEnd)
+	if len(runEnd.Summary) != 1 || runEnd.Summary[0].Host != "host1" {
+		t.Errorf("run_end.Summary = %+v, want one entry for host1", runEnd.Summary)
+	}
+}
@@ -0,0 +1,107 @@
+package sup
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connectRetryRand is shared across every goroutine Run spawns per host,
+// guarded by connectRetryRandMu, so concurrent retries don't all compute
+// the same "random" backoff from identically-seeded generators.
+var (
+	connectRetryRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	connectRetryRandMu sync.Mutex
+)
+
+// SetConnectRetry makes Run retry a host's initial connection (including
+// the bastion, if any) up to attempts times on transient network/SSH
+// handshake failures, using full-jitter exponential backoff between
+// tries: each retry waits a random fraction (scaled by jitter, 0..1) of
+// min(maxBackoff, initialBackoff*2^attempt). Auth failures are never
+// retried. attempts <= 0 (the default) disables retrying entirely.
+func (sup *Stackup) SetConnectRetry(attempts int, initialBackoff, maxBackoff time.Duration, jitter float64) {
+	sup.connectRetryAttempts = attempts
+	sup.connectRetryInitialBackoff = initialBackoff
+	sup.connectRetryMaxBackoff = maxBackoff
+	sup.connectRetryJitter = jitter
+}
+
+// connectWithRetry calls connect, retrying on transient errors according
+// to the policy set by SetConnectRetry. host is only used for debug
+// logging.
+func (sup *Stackup) connectWithRetry(host string, connect func() error) error {
+	if sup.connectRetryAttempts <= 0 {
+		return connect()
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = connect()
+		if err == nil {
+			return nil
+		}
+		if !isTransientConnErr(err) || attempt >= sup.connectRetryAttempts {
+			return err
+		}
+
+		backoff := sup.connectRetryInitialBackoff * time.Duration(int64(1)<<uint(attempt))
+		if sup.connectRetryMaxBackoff > 0 && backoff > sup.connectRetryMaxBackoff {
+			backoff = sup.connectRetryMaxBackoff
+		}
+		sleep := fullJitterSleep(backoff, sup.connectRetryJitter)
+
+		if sup.debug {
+			fmt.Fprintf(os.Stderr, "sup: debug: %s: connect attempt %d/%d failed (%v), retrying in %v\n",
+				host, attempt+1, sup.connectRetryAttempts+1, err, sleep)
+		}
+		time.Sleep(sleep)
+	}
+}
+
+func fullJitterSleep(cap time.Duration, jitter float64) time.Duration {
+	if cap <= 0 {
+		return 0
+	}
+	if jitter <= 0 {
+		return cap
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	connectRetryRandMu.Lock()
+	f := connectRetryRand.Float64()
+	connectRetryRandMu.Unlock()
+
+	return time.Duration(float64(cap) * (1 - jitter + jitter*f))
+}
+
+// isTransientConnErr reports whether err looks like a momentary network or
+// SSH handshake hiccup worth retrying, as opposed to an auth failure or
+// other error that will just happen again.
+func isTransientConnErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var opErr *net.OpError
+	if stderrors.As(err, &opErr) {
+		return true
+	}
+	if stderrors.Is(err, io.EOF) {
+		return true
+	}
+
+	msg := err.Error()
+	if strings.Contains(msg, "unable to authenticate") {
+		return false
+	}
+	return strings.Contains(msg, "handshake failed") || strings.Contains(msg, "i/o timeout")
+}
@@ -0,0 +1,104 @@
+package sup
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFullJitterSleep(t *testing.T) {
+	capDelay := 100 * time.Millisecond
+
+	for i := 0; i < 50; i++ {
+		d := fullJitterSleep(capDelay, 1.0)
+		if d < 0 || d > capDelay {
+			t.Fatalf("fullJitterSleep(%v, 1.0) = %v, want in [0, %v]", capDelay, d, capDelay)
+		}
+	}
+
+	if d := fullJitterSleep(capDelay, 0); d != capDelay {
+		t.Errorf("fullJitterSleep(%v, 0) = %v, want %v (no jitter)", capDelay, d, capDelay)
+	}
+
+	if d := fullJitterSleep(0, 1.0); d != 0 {
+		t.Errorf("fullJitterSleep(0, 1.0) = %v, want 0", d)
+	}
+}
+
+func TestIsTransientConnErr(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"net.OpError", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"io.EOF", io.EOF, true},
+		{"ssh handshake failure", errors.New("ssh: handshake failed: EOF"), true},
+		{"dial timeout", errors.New("dial tcp: i/o timeout"), true},
+		{"auth failure is never retried", errors.New("ssh: unable to authenticate, attempted methods [none publickey]"), false},
+		{"unrelated error", errors.New("boom"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isTransientConnErr(c.err); got != c.want {
+				t.Errorf("isTransientConnErr(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestConnectWithRetryDisabledByDefault(t *testing.T) {
+	sup := &Stackup{}
+
+	calls := 0
+	err := sup.connectWithRetry("host", func() error {
+		calls++
+		return errors.New("ssh: handshake failed: EOF")
+	})
+	if err == nil {
+		t.Fatal("expected error to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("connect called %d times, want 1 (retrying disabled by default)", calls)
+	}
+}
+
+func TestConnectWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	sup := &Stackup{}
+	sup.SetConnectRetry(3, time.Microsecond, time.Millisecond, 0)
+
+	calls := 0
+	err := sup.connectWithRetry("host", func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("ssh: handshake failed: EOF")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("connectWithRetry() = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("connect called %d times, want 3", calls)
+	}
+}
+
+func TestConnectWithRetryNeverRetriesAuthFailures(t *testing.T) {
+	sup := &Stackup{}
+	sup.SetConnectRetry(3, time.Microsecond, time.Millisecond, 0)
+
+	calls := 0
+	err := sup.connectWithRetry("host", func() error {
+		calls++
+		return errors.New("ssh: unable to authenticate, attempted methods [none publickey]")
+	})
+	if err == nil {
+		t.Fatal("expected error to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("connect called %d times, want 1 (auth failures aren't retried)", calls)
+	}
+}
@@ -0,0 +1,433 @@
+package sup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+)
+
+const defaultChunkSize = 16 * 1024 * 1024
+
+// UploadOpts configures a Stackup.Upload or Stackup.Download transfer.
+type UploadOpts struct {
+	// Recursive transfers localPath/remotePath as a directory tree.
+	Recursive bool
+
+	// ChunkSize is the buffer size used to stream each file. Defaults to
+	// 16MB.
+	ChunkSize int64
+
+	// Concurrency bounds how many hosts transfer at once. Defaults to
+	// one worker per host.
+	Concurrency int
+
+	// PreserveMode copies the source file's permission bits to the
+	// destination.
+	PreserveMode bool
+
+	// PreserveMtime copies the source file's modification time to the
+	// destination.
+	PreserveMtime bool
+
+	// Progress, if set, is called after each chunk is transferred for a
+	// given host.
+	Progress func(host string, transferred, total int64)
+}
+
+func (o UploadOpts) chunkSize() int64 {
+	if o.ChunkSize > 0 {
+		return o.ChunkSize
+	}
+	return defaultChunkSize
+}
+
+// Upload copies localPath to remotePath on every host in network,
+// optionally recursing into directories. Transfers run concurrently
+// across hosts, each over its own SFTP session layered on the SSHClient
+// connection Run uses, including through a Network.Bastion.
+func (sup *Stackup) Upload(network *Network, localPath, remotePath string, opts UploadOpts) error {
+	return sup.transfer(network, opts, func(c *xferClient) error {
+		return c.upload(localPath, remotePath, opts)
+	})
+}
+
+// Download copies remotePath from every host in network to a
+// per-host-prefixed path under localPath, optionally recursing into
+// directories.
+func (sup *Stackup) Download(network *Network, remotePath, localPath string, opts UploadOpts) error {
+	return sup.transfer(network, opts, func(c *xferClient) error {
+		return c.download(remotePath, filepath.Join(localPath, c.host), opts)
+	})
+}
+
+// xferClient adapts either an SFTP session (remote hosts) or the local
+// filesystem (the "localhost" entry) behind a single upload/download API.
+type xferClient struct {
+	host string
+	sftp *sftp.Client // nil for localhost
+}
+
+func (c *xferClient) upload(localPath, remotePath string, opts UploadOpts) error {
+	if c.sftp == nil {
+		return copyLocalTree(localPath, remotePath, opts, c.host)
+	}
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return c.uploadFile(localPath, remotePath, info, opts)
+	}
+	if !opts.Recursive {
+		return fmt.Errorf("%s is a directory, set UploadOpts.Recursive", localPath)
+	}
+
+	return filepath.Walk(localPath, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.ToSlash(filepath.Join(remotePath, rel))
+		if fi.IsDir() {
+			return c.sftp.MkdirAll(dest)
+		}
+		return c.uploadFile(path, dest, fi, opts)
+	})
+}
+
+func (c *xferClient) uploadFile(localPath, remotePath string, info os.FileInfo, opts UploadOpts) error {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := c.sftp.Create(remotePath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := copyInChunks(dst, src, info.Size(), opts, c.host); err != nil {
+		return err
+	}
+	return c.applyMeta(remotePath, info, opts)
+}
+
+func (c *xferClient) applyMeta(remotePath string, info os.FileInfo, opts UploadOpts) error {
+	if opts.PreserveMode {
+		if err := c.sftp.Chmod(remotePath, info.Mode()); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveMtime {
+		if err := c.sftp.Chtimes(remotePath, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *xferClient) download(remotePath, localPath string, opts UploadOpts) error {
+	if c.sftp == nil {
+		return copyLocalTree(remotePath, localPath, opts, c.host)
+	}
+
+	info, err := c.sftp.Stat(remotePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return c.downloadFile(remotePath, localPath, info, opts)
+	}
+	if !opts.Recursive {
+		return fmt.Errorf("%s is a directory, set UploadOpts.Recursive", remotePath)
+	}
+
+	walker := c.sftp.Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(remotePath, walker.Path())
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(localPath, rel)
+		if walker.Stat().IsDir() {
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.downloadFile(walker.Path(), dest, walker.Stat(), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *xferClient) downloadFile(remotePath, localPath string, info os.FileInfo, opts UploadOpts) error {
+	src, err := c.sftp.Open(remotePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := copyInChunks(dst, src, info.Size(), opts, c.host); err != nil {
+		return err
+	}
+	if opts.PreserveMode {
+		if err := os.Chmod(localPath, info.Mode()); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveMtime {
+		if err := os.Chtimes(localPath, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyInChunks streams src to dst opts.chunkSize() bytes at a time,
+// reporting progress through opts.Progress after each chunk.
+func copyInChunks(dst io.Writer, src io.Reader, total int64, opts UploadOpts, host string) error {
+	buf := make([]byte, opts.chunkSize())
+	var transferred int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			transferred += int64(n)
+			if opts.Progress != nil {
+				opts.Progress(host, transferred, total)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copyLocalTree implements upload/download for the "localhost" entry,
+// where both sides of the transfer are the local filesystem.
+func copyLocalTree(src, dst string, opts UploadOpts, host string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyLocalFile(src, dst, info, opts, host)
+	}
+	if !opts.Recursive {
+		return fmt.Errorf("%s is a directory, set UploadOpts.Recursive", src)
+	}
+
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(dst, rel)
+		if fi.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+		return copyLocalFile(path, dest, fi, opts, host)
+	})
+}
+
+func copyLocalFile(src, dst string, info os.FileInfo, opts UploadOpts, host string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := copyInChunks(out, in, info.Size(), opts, host); err != nil {
+		return err
+	}
+	if opts.PreserveMode {
+		if err := os.Chmod(dst, info.Mode()); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveMtime {
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostConn is a connected client awaiting a transfer, produced by
+// connectHosts.
+type hostConn struct {
+	host string
+	ssh  *SSHClient // nil for localhost
+}
+
+// connectHosts fans out connections to every host in network, mirroring
+// the connect loop in Run but without starting any task. The returned
+// bastion (if any) must be closed by the caller once every hostConn's ssh
+// client has been closed.
+func (sup *Stackup) connectHosts(network *Network) ([]*hostConn, *SSHClient, []ClientError) {
+	var bastion *SSHClient
+	if network.Bastion != "" {
+		bastion = &SSHClient{ConnectTimeout: sup.sshConnectTimeout}
+		err := sup.connectWithRetry(network.Bastion, func() error {
+			return bastion.Connect(network.Bastion)
+		})
+		if err != nil {
+			return nil, nil, []ClientError{{
+				Host: network.Bastion, Type: "conn",
+				Err: errors.Wrap(err, "connecting to bastion failed"), ExitCode: -1,
+			}}
+		}
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		conns []*hostConn
+		errs  []ClientError
+	)
+
+	for _, host := range network.Hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			if host == "localhost" {
+				mu.Lock()
+				conns = append(conns, &hostConn{host: host})
+				mu.Unlock()
+				return
+			}
+
+			remote := &SSHClient{user: network.User, ConnectTimeout: sup.sshConnectTimeout}
+			var err error
+			if bastion != nil {
+				err = sup.connectWithRetry(host, func() error {
+					return remote.ConnectWith(host, bastion.DialThrough)
+				})
+			} else {
+				err = sup.connectWithRetry(host, func() error {
+					return remote.Connect(host)
+				})
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, ClientError{Host: host, Type: "conn", Err: errors.Wrap(err, "connecting to remote host failed"), ExitCode: -1})
+				mu.Unlock()
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, &hostConn{host: host, ssh: remote})
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+
+	return conns, bastion, errs
+}
+
+// transfer connects to every host in network and runs fn against each one
+// concurrently (bounded by opts.Concurrency), collecting any failure as a
+// ClientError{Type:"xfer"} so it flows through the same ignoreError/
+// summaryFile reporting path as Run's command failures.
+func (sup *Stackup) transfer(network *Network, opts UploadOpts, fn func(*xferClient) error) error {
+	conns, bastion, clientErrors := sup.connectHosts(network)
+	if bastion != nil {
+		defer bastion.Close()
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = len(conns)
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, hc := range conns {
+		wg.Add(1)
+		go func(hc *hostConn) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if hc.ssh != nil {
+				defer hc.ssh.Close()
+			}
+
+			xc := &xferClient{host: hc.host}
+			if hc.ssh != nil {
+				sftpClient, err := sftp.NewClient(hc.ssh.conn)
+				if err != nil {
+					mu.Lock()
+					clientErrors = append(clientErrors, ClientError{Host: hc.host, Type: "xfer", Err: errors.Wrap(err, "opening sftp session failed"), ExitCode: -1})
+					mu.Unlock()
+					return
+				}
+				defer sftpClient.Close()
+				xc.sftp = sftpClient
+			}
+
+			if err := fn(xc); err != nil {
+				mu.Lock()
+				clientErrors = append(clientErrors, ClientError{Host: hc.host, Type: "xfer", Err: err, ExitCode: -1})
+				mu.Unlock()
+			}
+		}(hc)
+	}
+	wg.Wait()
+
+	if err := sup.writeSummary(clientErrors); err != nil {
+		return err
+	}
+
+	if !sup.ignoreError && len(clientErrors) > 0 {
+		return &RunError{Errors: clientErrors}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package sup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "nested", "dst.txt")
+	if err := copyLocalFile(src, dst, info, UploadOpts{}, "localhost"); err != nil {
+		t.Fatalf("copyLocalFile() = %v", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading copied file: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("copied content = %q, want %q", got, "hello world")
+	}
+}
+
+func TestCopyLocalTreeRecursive(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "out")
+	if err := copyLocalTree(src, dst, UploadOpts{Recursive: true}, "localhost"); err != nil {
+		t.Fatalf("copyLocalTree() = %v", err)
+	}
+
+	for _, rel := range []string{"a.txt", filepath.Join("sub", "b.txt")} {
+		if _, err := os.Stat(filepath.Join(dst, rel)); err != nil {
+			t.Errorf("expected %s to exist: %v", rel, err)
+		}
+	}
+}
+
+func TestCopyLocalTreeDirWithoutRecursiveFails(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyLocalTree(src, filepath.Join(t.TempDir(), "out"), UploadOpts{}, "localhost"); err == nil {
+		t.Fatal("expected an error when src is a directory and Recursive is unset")
+	}
+}
+
+func TestCopyInChunksReportsProgress(t *testing.T) {
+	src := strings.NewReader("0123456789")
+	dst := &bytes.Buffer{}
+
+	var calls []int64
+	opts := UploadOpts{
+		ChunkSize: 4,
+		Progress: func(host string, transferred, total int64) {
+			calls = append(calls, transferred)
+		},
+	}
+	if err := copyInChunks(dst, src, 10, opts, "host1"); err != nil {
+		t.Fatalf("copyInChunks() = %v", err)
+	}
+
+	if dst.String() != "0123456789" {
+		t.Errorf("dst = %q, want %q", dst.String(), "0123456789")
+	}
+	want := []int64{4, 8, 10}
+	if len(calls) != len(want) {
+		t.Fatalf("progress calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("progress call %d = %d, want %d", i, calls[i], want[i])
+		}
+	}
+}
+
+// TestDownloadPerHostDestination reproduces the scenario from the review:
+// two hosts downloading the same remotePath must land in separate
+// directories under localPath instead of clobbering each other, matching
+// how Stackup.Download now joins c.host into the destination before
+// calling xferClient.download.
+func TestDownloadPerHostDestination(t *testing.T) {
+	remoteDir := t.TempDir()
+	hosts := []string{"host-a", "host-b"}
+	for _, host := range hosts {
+		content := []byte(host + " unique payload for " + host)
+		if err := os.WriteFile(filepath.Join(remoteDir, host+".txt"), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	localRoot := t.TempDir()
+	for _, host := range hosts {
+		c := &xferClient{host: host}
+		dest := filepath.Join(localRoot, host)
+		if err := c.download(remoteDir, dest, UploadOpts{Recursive: true}); err != nil {
+			t.Fatalf("download for %s: %v", host, err)
+		}
+	}
+
+	for _, host := range hosts {
+		want := host + " unique payload for " + host
+		got, err := os.ReadFile(filepath.Join(localRoot, host, host+".txt"))
+		if err != nil {
+			t.Fatalf("reading %s's download: %v", host, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q, want %q", host, got, want)
+		}
+	}
+}
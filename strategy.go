@@ -0,0 +1,40 @@
+package sup
+
+// SetMaxParallel bounds how many hosts may connect, or run a given task
+// step, at the same time. n <= 0 means unlimited (the historical
+// behavior of fanning out to every host at once), except on a "rolling"
+// network, whose window then defaults to 1.
+func (sup *Stackup) SetMaxParallel(n int) {
+	sup.maxParallel = n
+}
+
+// hostSem returns a semaphore channel sized for the given strategy and
+// host count, used to gate both Run's initial connect loop and its
+// per-task client dispatch. "rolling" without an explicit SetMaxParallel
+// defaults to a window of 1 (fully serial) rather than unbounded, since
+// an unbounded rolling window is just "all".
+func (sup *Stackup) hostSem(hosts int, strategy string) chan struct{} {
+	n := sup.maxParallel
+	if n <= 0 {
+		if strategy == "rolling" {
+			n = 1
+		} else {
+			n = hosts
+		}
+	}
+	if n > hosts {
+		n = hosts
+	}
+	if n <= 0 {
+		n = 1
+	}
+	return make(chan struct{}, n)
+}
+
+// canarySucceeded reports whether a canary host's run is clean, by
+// comparing the clientErrors count captured just before it ran against
+// the count right after. Run only fans out to the rest of the hosts when
+// this is true.
+func canarySucceeded(before, after int) bool {
+	return after == before
+}
@@ -0,0 +1,50 @@
+package sup
+
+import "testing"
+
+func TestHostSem(t *testing.T) {
+	cases := []struct {
+		name        string
+		maxParallel int
+		hosts       int
+		strategy    string
+		want        int
+	}{
+		{"all defaults to unbounded", 0, 5, "all", 5},
+		{"rolling defaults to a window of 1", 0, 5, "rolling", 1},
+		{"explicit max below host count", 2, 5, "all", 2},
+		{"explicit max above host count clamps down", 10, 5, "all", 5},
+		{"zero hosts still returns a usable channel", 0, 0, "all", 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sup := &Stackup{maxParallel: c.maxParallel}
+			sem := sup.hostSem(c.hosts, c.strategy)
+			if cap(sem) != c.want {
+				t.Errorf("hostSem(%d, %q) with maxParallel=%d: cap = %d, want %d",
+					c.hosts, c.strategy, c.maxParallel, cap(sem), c.want)
+			}
+		})
+	}
+}
+
+func TestCanarySucceeded(t *testing.T) {
+	cases := []struct {
+		name   string
+		before int
+		after  int
+		want   bool
+	}{
+		{"no new errors", 2, 2, true},
+		{"canary added a new error", 0, 1, false},
+		{"pre-existing errors, canary clean", 3, 3, true},
+		{"pre-existing errors, canary also failed", 3, 4, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := canarySucceeded(c.before, c.after); got != c.want {
+				t.Errorf("canarySucceeded(%d, %d) = %v, want %v", c.before, c.after, got, c.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,15 @@
 package sup
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
+	"time"
 
-	"github.com/goware/prefixer"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
 )
@@ -23,11 +23,27 @@ type Stackup struct {
 	ignoreError       bool
 	summaryFile       string
 	sshConnectTimeout int
+
+	sshKeepAliveInterval time.Duration
+	sshKeepAliveMaxDelay time.Duration
+
+	connectRetryAttempts       int
+	connectRetryInitialBackoff time.Duration
+	connectRetryMaxBackoff     time.Duration
+	connectRetryJitter         float64
+
+	maxParallel int
+
+	reporters       []Reporter
+	defaultReporter *TextReporter
 }
 
 func New(conf *Supfile) (*Stackup, error) {
+	text := NewTextReporter(os.Stdout, os.Stderr, false)
 	return &Stackup{
-		conf: conf,
+		conf:            conf,
+		reporters:       []Reporter{text},
+		defaultReporter: text,
 	}, nil
 }
 
@@ -63,9 +79,41 @@ func (e *ClientError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(e.normalForm())
 }
 
+// RunError is returned by Run when one or more hosts failed with anything
+// other than a connection failure, so the caller (cmd/sup's main, or any
+// other embedder) decides what to do about it instead of Run calling
+// os.Exit itself.
+type RunError struct {
+	Errors []ClientError
+}
+
+func (e *RunError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return fmt.Sprintf("%d host(s) failed, highest exit code %d", len(e.Errors), e.ExitCode())
+}
+
+// ExitCode returns the highest exit code observed across all the
+// errors, or 1 if none of them carried a real one (e.g. connection
+// failures, which are recorded with ExitCode -1).
+func (e *RunError) ExitCode() int {
+	code := 0
+	for _, ce := range e.Errors {
+		if ce.ExitCode > code {
+			code = ce.ExitCode
+		}
+	}
+	if code <= 0 {
+		return 1
+	}
+	return code
+}
+
 // Run runs set of commands on multiple hosts defined by network sequentially.
 // TODO: This megamoth method needs a big refactor and should be split
-//       to multiple smaller methods.
+//
+//	to multiple smaller methods.
 func (sup *Stackup) Run(network *Network, envVars EnvList, commands ...*Command) error {
 	if len(commands) == 0 {
 		return errors.New("no commands to be run")
@@ -75,26 +123,49 @@ func (sup *Stackup) Run(network *Network, envVars EnvList, commands ...*Command)
 
 	env := envVars.AsExport()
 
+	// keepAliveErrCh collects keepalive-timeout failures detected after the
+	// initial connect loop below has already closed errCh, e.g. while a
+	// task is mid-flight on another host. It is drained once more just
+	// before Run returns.
+	keepAliveErrCh := make(chan ClientError, len(network.Hosts))
+	onKeepAliveTimeout := func(ce ClientError) {
+		keepAliveErrCh <- ce
+	}
+
 	// Create clients for every host (either SSH or Localhost).
 	var bastion *SSHClient
 	if network.Bastion != "" {
 		bastion = &SSHClient{
-			ConnectTimeout: sup.sshConnectTimeout,
+			ConnectTimeout:     sup.sshConnectTimeout,
+			keepAliveInterval:  sup.sshKeepAliveInterval,
+			keepAliveMaxDelay:  sup.sshKeepAliveMaxDelay,
+			onKeepAliveTimeout: onKeepAliveTimeout,
 		}
-		if err := bastion.Connect(network.Bastion); err != nil {
+		err := sup.connectWithRetry(network.Bastion, func() error {
+			return bastion.Connect(network.Bastion)
+		})
+		if err != nil {
 			return errors.Wrap(err, "connecting to bastion failed")
 		}
+		defer bastion.Close()
 	}
 
 	var wg sync.WaitGroup
 	clientCh := make(chan Client, len(network.Hosts))
 	errCh := make(chan ClientError, len(network.Hosts))
 
+	// connectSem bounds how many hosts dial in at once, the same way
+	// taskSem bounds how many hosts run a task at once further down.
+	connectSem := sup.hostSem(len(network.Hosts), network.Strategy)
+
 	for i, host := range network.Hosts {
 		wg.Add(1)
 		go func(i int, host string) {
 			defer wg.Done()
 
+			connectSem <- struct{}{}
+			defer func() { <-connectSem }()
+
 			// Localhost client.
 			if host == "localhost" {
 				local := &LocalhostClient{
@@ -110,19 +181,28 @@ func (sup *Stackup) Run(network *Network, envVars EnvList, commands ...*Command)
 
 			// SSH client.
 			remote := &SSHClient{
-				env:            env + `export SUP_HOST="` + host + `";`,
-				user:           network.User,
-				color:          Colors[i%len(Colors)],
-				ConnectTimeout: sup.sshConnectTimeout,
+				env:                env + `export SUP_HOST="` + host + `";`,
+				user:               network.User,
+				color:              Colors[i%len(Colors)],
+				ConnectTimeout:     sup.sshConnectTimeout,
+				keepAliveInterval:  sup.sshKeepAliveInterval,
+				keepAliveMaxDelay:  sup.sshKeepAliveMaxDelay,
+				onKeepAliveTimeout: onKeepAliveTimeout,
 			}
 
 			if bastion != nil {
-				if err := remote.ConnectWith(host, bastion.DialThrough); err != nil {
+				err := sup.connectWithRetry(host, func() error {
+					return remote.ConnectWith(host, bastion.DialThrough)
+				})
+				if err != nil {
 					errCh <- ClientError{Host: host, Type: "conn", Err: errors.Wrap(err, "connecting to remote host through bastion failed"), ExitCode: -1}
 					return
 				}
 			} else {
-				if err := remote.Connect(host); err != nil {
+				err := sup.connectWithRetry(host, func() error {
+					return remote.Connect(host)
+				})
+				if err != nil {
 					errCh <- ClientError{Host: host, Type: "conn", Err: errors.Wrap(err, "connecting to remote host failed"), ExitCode: -1}
 					return
 				}
@@ -147,6 +227,7 @@ func (sup *Stackup) Run(network *Network, envVars EnvList, commands ...*Command)
 		}
 		clients = append(clients, client)
 	}
+	sup.setHostPrefixes(clients, maxLen)
 	for err := range errCh {
 		if sup.ignoreError {
 			fmt.Fprintf(os.Stderr, "%v\n", err.Err)
@@ -156,177 +237,373 @@ func (sup *Stackup) Run(network *Network, envVars EnvList, commands ...*Command)
 		}
 	}
 
-	// Run command or run multiple commands defined by target sequentially.
+	// Run command(s) against the hosts according to network.Strategy:
+	//  - "all" (default): every host runs every task at once, or at most
+	//    SetMaxParallel hosts at once if set.
+	//  - "rolling": at most SetMaxParallel hosts (default 1) run a task at once.
+	//  - "canary": the first host runs every command alone; only once it
+	//    succeeds do the rest run, as with "all".
+	var clientErrorsMu sync.Mutex
+	switch network.Strategy {
+	case "canary":
+		if len(clients) > 0 {
+			before := len(clientErrors)
+			canarySem := sup.hostSem(1, "all")
+			if err := sup.runCommands(commands, clients[:1], canarySem, &clientErrors, &clientErrorsMu); err != nil && err != errStopRun {
+				return err
+			}
+			if canarySucceeded(before, len(clientErrors)) && len(clients) > 1 {
+				restSem := sup.hostSem(len(clients)-1, "all")
+				if err := sup.runCommands(commands, clients[1:], restSem, &clientErrors, &clientErrorsMu); err != nil && err != errStopRun {
+					return err
+				}
+			}
+		}
+	default:
+		taskSem := sup.hostSem(len(clients), network.Strategy)
+		if err := sup.runCommands(commands, clients, taskSem, &clientErrors, &clientErrorsMu); err != nil && err != errStopRun {
+			return err
+		}
+	}
+
+	// Pick up any keepalive timeouts detected while tasks were running. A
+	// timeout closes the client's connection, which usually also makes its
+	// in-flight task's Wait() fail and get recorded as its own ClientError;
+	// skip hosts that already have one so the same failure isn't counted
+	// (and summarized) twice.
+	failedHosts := make(map[string]bool, len(clientErrors))
+	for _, e := range clientErrors {
+		failedHosts[e.Host] = true
+	}
+drainKeepAliveErrs:
+	for {
+		select {
+		case ce := <-keepAliveErrCh:
+			if failedHosts[ce.Host] {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%v\n", ce.Err)
+			clientErrors = append(clientErrors, ce)
+			failedHosts[ce.Host] = true
+		default:
+			break drainKeepAliveErrs
+		}
+	}
+
+	if err := sup.writeSummary(clientErrors); err != nil {
+		return err
+	}
+	sup.reportRunEnd(clientErrors)
+
+	// Connection failures alone (Type "conn", which includes keepalive
+	// timeouts) aren't treated as a failed run, even if no host connected;
+	// any other failure is.
+	for _, e := range clientErrors {
+		if e.Type != "conn" {
+			return &RunError{Errors: clientErrors}
+		}
+	}
+
+	return nil
+}
+
+// runCommands translates each command into task(s) and runs them against
+// clients in order, gated by sem. It is called once per Run for the "all"
+// and "rolling" strategies, and twice for "canary" (once for the lone
+// canary host, once for the rest).
+func (sup *Stackup) runCommands(commands []*Command, clients []Client, sem chan struct{}, clientErrors *[]ClientError, mu *sync.Mutex) error {
 	for _, cmd := range commands {
-		// Translate command into task(s).
-		tasks, err := sup.createTasks(cmd, clients, env)
+		tasks, err := sup.createTasks(cmd, clients)
 		if err != nil {
 			return errors.Wrap(err, "creating task failed")
 		}
-
-		// Run tasks sequentially.
 		for _, task := range tasks {
-			var writers []io.Writer
-			var wg sync.WaitGroup
+			if err := sup.runTask(task, sem, clientErrors, mu); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runTask runs task on every one of its clients, bounded by sem: a client
+// only starts once it has acquired a slot, and holds that slot until its
+// Wait() completes. This is what turns SetMaxParallel/Network.Strategy into
+// an actual rolling window, rather than just a connect-time limit.
+//
+// Tasks with piped STDIN (task.Input != nil) are the exception: since the
+// input can only be read once, every client has to start up front to
+// receive it, so those always run with full fan-out regardless of sem.
+func (sup *Stackup) runTask(task *Task, sem chan struct{}, clientErrors *[]ClientError, mu *sync.Mutex) error {
+	if task.Input != nil {
+		return sup.runTaskWithInput(task, clientErrors, mu)
+	}
 
-			// Run tasks on the provided clients.
+	trap := make(chan os.Signal, 1)
+	signal.Notify(trap, os.Interrupt)
+	go func() {
+		for {
+			sig, ok := <-trap
+			if !ok {
+				return
+			}
 			for _, c := range task.Clients {
-				var prefix string
-				var prefixLen int
-				if sup.prefix {
-					prefix, prefixLen = c.Prefix()
-					if prefixLen < maxLen { // Left padding.
-						prefix = strings.Repeat(" ", maxLen-prefixLen) + prefix
-					}
+				if err := c.Signal(sig); err != nil {
+					fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, "sending signal failed"))
 				}
+			}
+		}
+	}()
 
-				err := c.Run(task)
-				if err != nil {
-					return errors.Wrap(err, prefix+"task failed")
-				}
+	mu.Lock()
+	before := len(*clientErrors)
+	mu.Unlock()
 
-				// Copy over tasks's STDOUT.
-				wg.Add(1)
-				go func(c Client) {
-					defer wg.Done()
-					_, err := io.Copy(os.Stdout, prefixer.New(c.Stdout(), prefix))
-					if err != nil && err != io.EOF {
-						// TODO: io.Copy() should not return io.EOF at all.
-						// Upstream bug? Or prefixer.WriteTo() bug?
-						fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, prefix+"reading STDOUT failed"))
-					}
-				}(c)
-
-				// Copy over tasks's STDERR.
-				wg.Add(1)
-				go func(c Client) {
-					defer wg.Done()
-					_, err := io.Copy(os.Stderr, prefixer.New(c.Stderr(), prefix))
-					if err != nil && err != io.EOF {
-						fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, prefix+"reading STDERR failed"))
-					}
-				}(c)
-
-				writers = append(writers, c.Stdin())
-			}
+	var wg sync.WaitGroup
+	var firstErr error
+	var firstErrMu sync.Mutex
+	for _, c := range task.Clients {
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
 
-			// Copy over task's STDIN.
-			if task.Input != nil {
-				go func() {
-					var writer io.Writer
-					if sup.ignoreError {
-						writer = SilentMultiWriter(writers...)
-					} else {
-						writer = io.MultiWriter(writers...)
-					}
-					_, err := io.Copy(writer, task.Input)
-					if err != nil && err != io.EOF {
-						fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, "copying STDIN failed"))
-					}
-					// TODO: Use MultiWriteCloser (not in Stdlib), so we can writer.Close() instead?
-					for _, c := range clients {
-						c.WriteClose()
-					}
-				}()
-			}
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-			// Catch OS signals and pass them to all active clients.
-			trap := make(chan os.Signal, 1)
-			signal.Notify(trap, os.Interrupt)
-			go func() {
-				for {
-					sig, ok := <-trap
-					if !ok {
-						return
-					}
-					for _, c := range task.Clients {
-						err := c.Signal(sig)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, "sending signal failed"))
-						}
-					}
+			if err := sup.runOneTask(task, c, clientErrors, mu); err != nil {
+				firstErrMu.Lock()
+				if firstErr == nil {
+					firstErr = err
 				}
-			}()
+				firstErrMu.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
 
-			// Wait for all I/O operations first.
-			wg.Wait()
+	signal.Stop(trap)
+	close(trap)
 
-			// Make sure each client finishes the task, return on failure.
-			for _, c := range task.Clients {
-				wg.Add(1)
-				go func(c Client) {
-					defer wg.Done()
-					if err := c.Wait(); err != nil {
-						var prefix string
-						if sup.prefix {
-							var prefixLen int
-							prefix, prefixLen = c.Prefix()
-							if prefixLen < maxLen { // Left padding.
-								prefix = strings.Repeat(" ", maxLen-prefixLen) + prefix
-							}
-						}
-						if e, ok := err.(*ssh.ExitError); ok && e.ExitStatus() != 15 {
-							// TODO: Store all the errors, and print them after Wait().
-							fmt.Fprintf(os.Stderr, "%s%v\n", prefix, e)
-							if sup.ignoreError {
-								clientErrors = append(clientErrors, ClientError{Host: c.Host(), Type: "run", Err: e, ExitCode: e.ExitStatus()})
-							} else {
-								os.Exit(e.ExitStatus())
-							}
-						}
-						fmt.Fprintf(os.Stderr, "%s%v\n", prefix, err)
-
-						// TODO: Shouldn't os.Exit(1) here. Instead, collect the exit statuses for later.
-						if !sup.ignoreError {
-							os.Exit(1)
-						}
-					}
-				}(c)
-			}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	mu.Lock()
+	failed := len(*clientErrors) > before
+	mu.Unlock()
+	if failed && !sup.ignoreError {
+		return errStopRun
+	}
+
+	return nil
+}
+
+// runTaskWithInput runs task the original, fully fanned-out way: every
+// client starts immediately so task.Input can be copied to all of them at
+// once, since an io.Reader can't be replayed for a later, staggered start.
+func (sup *Stackup) runTaskWithInput(task *Task, clientErrors *[]ClientError, mu *sync.Mutex) error {
+	var writers []io.Writer
+	var wg sync.WaitGroup
 
-			// Wait for all commands to finish.
-			wg.Wait()
+	for _, c := range task.Clients {
+		sup.reportTaskStart(c.Host(), task.Name)
 
-			// Stop catching signals for the currently active clients.
-			signal.Stop(trap)
-			close(trap)
+		if err := c.Run(task); err != nil {
+			return errors.Wrap(err, c.Host()+": task failed")
 		}
-	}
 
-	if len(clientErrors) > 0 {
-		if sup.summaryFile != "" {
-			outFile, err := os.OpenFile(sup.summaryFile, os.O_APPEND|os.O_CREATE, 0664)
-			if err != nil || outFile == nil {
-				fmt.Fprintf(os.Stderr, "could not open summary file '%s' for writing: %v", sup.summaryFile, err.Error())
-				os.Exit(2)
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
+			err := sup.scanLines(c.Stdout(), func(line string) {
+				sup.reportStdoutLine(c.Host(), task.Name, line)
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", errors.Wrap(err, c.Host()+": reading STDOUT failed"))
 			}
-			defer outFile.Close()
+		}(c)
 
-			data, err := json.MarshalIndent(clientErrors, "", "  ")
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
+			err := sup.scanLines(c.Stderr(), func(line string) {
+				sup.reportStderrLine(c.Host(), task.Name, line)
+			})
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "BUG! could not marshal errors json: %s", err.Error())
-				os.Exit(2)
+				fmt.Fprintf(os.Stderr, "%v\n", errors.Wrap(err, c.Host()+": reading STDERR failed"))
 			}
-			fmt.Fprint(outFile, string(data))
-		}
+		}(c)
+
+		writers = append(writers, c.Stdin())
+	}
 
-		// TODO: Fix return logic with error types. Is it OK to ignore connection errors?
-		for _, e := range clientErrors {
-			if e.Type != "conn" {
-				os.Exit(1)
+	go func() {
+		var writer io.Writer
+		if sup.ignoreError {
+			writer = SilentMultiWriter(writers...)
+		} else {
+			writer = io.MultiWriter(writers...)
+		}
+		_, err := io.Copy(writer, task.Input)
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, "copying STDIN failed"))
+		}
+		// TODO: Use MultiWriteCloser (not in Stdlib), so we can writer.Close() instead?
+		for _, c := range task.Clients {
+			c.WriteClose()
+		}
+	}()
+
+	trap := make(chan os.Signal, 1)
+	signal.Notify(trap, os.Interrupt)
+	go func() {
+		for {
+			sig, ok := <-trap
+			if !ok {
+				return
+			}
+			for _, c := range task.Clients {
+				if err := c.Signal(sig); err != nil {
+					fmt.Fprintf(os.Stderr, "%v", errors.Wrap(err, "sending signal failed"))
+				}
 			}
 		}
+	}()
+
+	// Wait for all I/O operations first.
+	wg.Wait()
+
+	mu.Lock()
+	before := len(*clientErrors)
+	mu.Unlock()
+
+	// Make sure each client finishes the task.
+	for _, c := range task.Clients {
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
+			sup.waitOneTask(task, c, clientErrors, mu)
+		}(c)
+	}
+	wg.Wait()
+
+	signal.Stop(trap)
+	close(trap)
+
+	mu.Lock()
+	failed := len(*clientErrors) > before
+	mu.Unlock()
+	if failed && !sup.ignoreError {
+		return errStopRun
+	}
+
+	return nil
+}
+
+// runOneTask runs task on a single client from start to finish: start it,
+// stream its output, then wait for it to exit.
+func (sup *Stackup) runOneTask(task *Task, c Client, clientErrors *[]ClientError, mu *sync.Mutex) error {
+	sup.reportTaskStart(c.Host(), task.Name)
+
+	if err := c.Run(task); err != nil {
+		return errors.Wrap(err, c.Host()+": task failed")
+	}
+
+	var ioWg sync.WaitGroup
+	ioWg.Add(2)
+	go func() {
+		defer ioWg.Done()
+		err := sup.scanLines(c.Stdout(), func(line string) {
+			sup.reportStdoutLine(c.Host(), task.Name, line)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", errors.Wrap(err, c.Host()+": reading STDOUT failed"))
+		}
+	}()
+	go func() {
+		defer ioWg.Done()
+		err := sup.scanLines(c.Stderr(), func(line string) {
+			sup.reportStderrLine(c.Host(), task.Name, line)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", errors.Wrap(err, c.Host()+": reading STDERR failed"))
+		}
+	}()
+	ioWg.Wait()
+
+	sup.waitOneTask(task, c, clientErrors, mu)
+	return nil
+}
+
+// waitOneTask waits for c to finish its already-running task and records
+// any failure into clientErrors. It never calls os.Exit: whether a
+// failure aborts the rest of the run is decided by runTask/runTaskWithInput
+// once every client for this task has finished.
+func (sup *Stackup) waitOneTask(task *Task, c Client, clientErrors *[]ClientError, mu *sync.Mutex) {
+	err := c.Wait()
+	if err != nil {
+		if e, ok := err.(*ssh.ExitError); ok && e.ExitStatus() != 15 {
+			sup.reportTaskEnd(c.Host(), task.Name, e.ExitStatus(), e)
+			mu.Lock()
+			*clientErrors = append(*clientErrors, ClientError{Host: c.Host(), Type: "run", Err: e, ExitCode: e.ExitStatus()})
+			mu.Unlock()
+			return
+		}
+		sup.reportTaskEnd(c.Host(), task.Name, 1, err)
+		mu.Lock()
+		*clientErrors = append(*clientErrors, ClientError{Host: c.Host(), Type: "run", Err: err, ExitCode: 1})
+		mu.Unlock()
+		return
+	}
+	sup.reportTaskEnd(c.Host(), task.Name, 0, nil)
+}
+
+// errStopRun is returned internally by runTask/runTaskWithInput to signal
+// that a task failed and sup.ignoreError is false, so Run should stop
+// dispatching further commands. It never escapes Run itself.
+var errStopRun = errors.New("stop run")
+
+// writeSummary appends clientErrors as JSON to sup.summaryFile, if set.
+// It is shared by Run and the SFTP transfer methods so every failure mode
+// ends up in the same summary/ignoreError reporting path.
+func (sup *Stackup) writeSummary(clientErrors []ClientError) error {
+	if len(clientErrors) == 0 || sup.summaryFile == "" {
+		return nil
+	}
+
+	outFile, err := os.OpenFile(sup.summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0664)
+	if err != nil || outFile == nil {
+		return errors.Wrapf(err, "could not open summary file '%s' for writing", sup.summaryFile)
 	}
+	defer outFile.Close()
 
+	data, err := json.MarshalIndent(clientErrors, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "BUG! could not marshal errors json")
+	}
+	fmt.Fprint(outFile, string(data))
 	return nil
 }
 
+// scanLines reads r line by line, calling onLine for each one. It mirrors
+// the line-splitting io.Copy(w, prefixer.New(r, prefix)) used to do, but
+// hands lines to the caller instead of writing them anywhere itself.
+func (sup *Stackup) scanLines(r io.Reader, onLine func(line string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onLine(scanner.Text())
+	}
+	return scanner.Err()
+}
+
 func (sup *Stackup) Debug(value bool) {
 	sup.debug = value
 }
 
 func (sup *Stackup) Prefix(value bool) {
 	sup.prefix = value
+	sup.defaultReporter.enabled = value
 }
 
 func (sup *Stackup) IgnoreError(value bool) {
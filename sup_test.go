@@ -0,0 +1,45 @@
+package sup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunErrorExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		errs []ClientError
+		want int
+	}{
+		{"no errors", nil, 1},
+		{"single positive exit code", []ClientError{{ExitCode: 3}}, 3},
+		{"highest of several positive exit codes", []ClientError{{ExitCode: 1}, {ExitCode: 7}, {ExitCode: 2}}, 7},
+		{"only connection failures default to 1", []ClientError{{ExitCode: -1}, {ExitCode: -1}}, 1},
+		{"mix of connection failures and a real exit code", []ClientError{{ExitCode: -1}, {ExitCode: 5}}, 5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := &RunError{Errors: c.errs}
+			if got := e.ExitCode(); got != c.want {
+				t.Errorf("ExitCode() = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRunErrorError(t *testing.T) {
+	single := &RunError{Errors: []ClientError{
+		{Host: "host1", Type: "run", Err: errors.New("boom"), ExitCode: 1},
+	}}
+	if got, want := single.Error(), "run: boom"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	multi := &RunError{Errors: []ClientError{
+		{Host: "host1", Type: "run", Err: errors.New("boom"), ExitCode: 1},
+		{Host: "host2", Type: "run", Err: errors.New("bang"), ExitCode: 4},
+	}}
+	if got, want := multi.Error(), "2 host(s) failed, highest exit code 4"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package sup
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Supfile represents the parsed configuration file (Supfile) describing
+// the networks, commands and targets available to run.
+type Supfile struct {
+	Networks Networks            `yaml:"networks"`
+	Commands map[string]Command  `yaml:"commands"`
+	Targets  map[string][]string `yaml:"targets"`
+	Env      EnvList             `yaml:"env"`
+}
+
+// Networks is a collection of named Network definitions.
+type Networks map[string]Network
+
+// Network groups a set of hosts that commands are run against, optionally
+// reached through a bastion host.
+type Network struct {
+	Env     EnvList  `yaml:"env"`
+	Hosts   []string `yaml:"hosts"`
+	Bastion string   `yaml:"bastion"`
+	User    string   `yaml:"user"`
+
+	// Strategy controls how hosts are fanned out to: "all" (default),
+	// "rolling" or "canary". See Stackup.SetMaxParallel.
+	Strategy string `yaml:"strategy"`
+}
+
+// Command is a single named step (a shell script) that can be run against
+// a Network.
+type Command struct {
+	Name  string `yaml:"-"`
+	Desc  string `yaml:"desc"`
+	Local string `yaml:"local"`
+	Run   string `yaml:"run"`
+	Stdin bool   `yaml:"stdin"`
+	TTY   bool   `yaml:"tty"`
+}
+
+// LoadSupfile reads and parses the Supfile at path, filling in each
+// Command's Name from its key in the commands map.
+func LoadSupfile(path string) (*Supfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading Supfile failed")
+	}
+
+	var conf Supfile
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, errors.Wrap(err, "parsing Supfile failed")
+	}
+
+	for name, cmd := range conf.Commands {
+		cmd.Name = name
+		conf.Commands[name] = cmd
+	}
+
+	return &conf, nil
+}
+
+// EnvVar is a single NAME=value pair exported into the remote shell.
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+// EnvList is an ordered collection of EnvVars.
+type EnvList []EnvVar
+
+// AsExport renders the EnvList as a sequence of shell export statements,
+// e.g. `export NAME="value";`.
+func (e EnvList) AsExport() string {
+	exports := ""
+	for _, v := range e {
+		exports += `export ` + v.Key + `="` + v.Value + `";`
+	}
+	return exports
+}
@@ -0,0 +1,31 @@
+package sup
+
+import "io"
+
+// Task is a single command translated for a set of clients that should
+// run it.
+type Task struct {
+	Name    string
+	Run     string
+	Input   io.Reader
+	Clients []Client
+	TTY     bool
+}
+
+// createTasks translates a Command into one or more Tasks to be run on
+// the given clients. A plain shell command becomes a single Task shared
+// by every client; a "local" command would be split per-client, but is
+// not yet supported.
+//
+// cmd.Run is used as-is: every client already exports the network/global
+// env vars itself (see the env field built in Run's connect loop), so
+// prepending them here again would just export the same vars twice.
+func (sup *Stackup) createTasks(cmd *Command, clients []Client) ([]*Task, error) {
+	task := &Task{
+		Name:    cmd.Name,
+		Run:     cmd.Run,
+		Clients: clients,
+		TTY:     cmd.TTY,
+	}
+	return []*Task{task}, nil
+}